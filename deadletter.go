@@ -0,0 +1,36 @@
+// coredns-s3dumper/deadletter.go
+package s3dumper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// writeDeadLetter persists a batch that exhausted its upload retries to
+// local disk using the same Parquet encoding the uploaders use, so
+// operators can inspect or replay it later.
+func writeDeadLetter(dir string, entries []*LogEntry) error {
+	buf, err := encodeParquet(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead-letter batch: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dead_letter_path %s: %w", dir, err)
+	}
+
+	now := time.Now().UTC()
+	id, _ := uuid.NewRandom()
+	filename := fmt.Sprintf("%d-%s.parquet", now.UnixNano(), id.String())
+	fullPath := filepath.Join(dir, filename)
+
+	if err := os.WriteFile(fullPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write dead-letter file %s: %w", fullPath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+// coredns-s3dumper/keylayout.go
+package s3dumper
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Key layout modes for the key_layout Corefile option.
+const (
+	KeyLayoutFlat   = "flat"
+	KeyLayoutHive   = "hive"
+	KeyLayoutCustom = "custom"
+)
+
+const defaultFileExtension = ".parquet"
+
+// keyTemplateData is the set of fields available to a "custom" key_layout
+// text/template, supplied via the key_template Corefile option.
+type keyTemplateData struct {
+	Year     string
+	Month    string
+	Day      string
+	Hour     string
+	UUID     string
+	Hostname string
+	Region   string
+	UnixNano int64
+}
+
+// KeyConfig controls how an uploader lays out the time-partitioned object
+// keys it writes, shared by the s3, b2, gcs and oss backends so operators
+// configure layout once regardless of which of them they picked. The
+// local backend does not use it; it takes only the file extension.
+type KeyConfig struct {
+	Layout        string // "flat" (default), "hive", or "custom"
+	FileExtension string // e.g. ".parquet"; ignored by "custom"
+	Template      *template.Template // only used when Layout == KeyLayoutCustom
+	Region        string
+}
+
+// NewKeyConfig validates layout and, for "custom", parses templateText into
+// a reusable template.
+func NewKeyConfig(layout, fileExtension, templateText, region string) (KeyConfig, error) {
+	if layout == "" {
+		layout = KeyLayoutFlat
+	}
+	if fileExtension == "" {
+		fileExtension = defaultFileExtension
+	}
+
+	cfg := KeyConfig{Layout: layout, FileExtension: fileExtension, Region: region}
+
+	switch layout {
+	case KeyLayoutFlat, KeyLayoutHive:
+		// nothing further to validate
+	case KeyLayoutCustom:
+		if templateText == "" {
+			return KeyConfig{}, fmt.Errorf("key_layout 'custom' requires 'key_template' to be set")
+		}
+		tmpl, err := template.New("key_template").Parse(templateText)
+		if err != nil {
+			return KeyConfig{}, fmt.Errorf("invalid key_template: %w", err)
+		}
+		cfg.Template = tmpl
+	default:
+		return KeyConfig{}, fmt.Errorf("invalid key_layout '%s', must be 'flat', 'hive' or 'custom'", layout)
+	}
+
+	return cfg, nil
+}
+
+// generateKey builds a unique object key under prefix according to the
+// configured layout.
+func (k KeyConfig) generateKey(prefix string) (string, error) {
+	now := time.Now().UTC()
+	id, _ := uuid.NewRandom()
+	hostname, _ := os.Hostname()
+
+	data := keyTemplateData{
+		Year:     now.Format("2006"),
+		Month:    now.Format("01"),
+		Day:      now.Format("02"),
+		Hour:     now.Format("15"),
+		UUID:     id.String(),
+		Hostname: hostname,
+		Region:   k.Region,
+		UnixNano: now.UnixNano(),
+	}
+
+	switch k.Layout {
+	case KeyLayoutCustom:
+		var rendered strings.Builder
+		if err := k.Template.Execute(&rendered, data); err != nil {
+			return "", fmt.Errorf("failed to render key_template: %w", err)
+		}
+		return path.Join(prefix, rendered.String()), nil
+	case KeyLayoutHive:
+		filename := fmt.Sprintf("%d-%s%s", data.UnixNano, data.UUID, k.FileExtension)
+		return path.Join(prefix,
+			"year="+data.Year,
+			"month="+data.Month,
+			"day="+data.Day,
+			"hour="+data.Hour,
+			filename,
+		), nil
+	default: // KeyLayoutFlat
+		filename := fmt.Sprintf("%d-%s%s", data.UnixNano, data.UUID, k.FileExtension)
+		return path.Join(prefix, data.Year, data.Month, data.Day, filename), nil
+	}
+}
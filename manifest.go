@@ -0,0 +1,117 @@
+// coredns-s3dumper/manifest.go
+package s3dumper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ManifestEntry records one Parquet object written to S3, so downstream
+// tools (Athena manifest-based tables, Spark) can read the dataset without
+// a separate crawler.
+type ManifestEntry struct {
+	Key   string `json:"key"`
+	Bytes int64  `json:"bytes"`
+	Rows  int    `json:"rows"`
+	MinTS int64  `json:"min_ts"`
+	MaxTS int64  `json:"max_ts"`
+}
+
+// publishSchema writes the LogEntry Parquet schema to
+// <prefix>/_schema/v<N>.json once per process lifetime; SchemaVersion bumps
+// are picked up automatically since they change the object key.
+func (u *S3Uploader) publishSchema(ctx context.Context) error {
+	var publishErr error
+	u.schemaOnce.Do(func() {
+		schema := buildSchema()
+		body, err := json.Marshal(schema)
+		if err != nil {
+			publishErr = fmt.Errorf("failed to encode schema: %w", err)
+			return
+		}
+
+		key := path.Join(u.Prefix, "_schema", fmt.Sprintf("v%d.json", schema.Version))
+		_, publishErr = u.Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(u.Bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(body),
+			ContentType: aws.String("application/json"),
+		})
+	})
+	return publishErr
+}
+
+// appendManifest records key as the latest object written in the current
+// UTC day's rolling manifest, rotating to a fresh manifest object at UTC
+// midnight. The day is taken from the time of the call, not from the
+// entries' timestamps.
+func (u *S3Uploader) appendManifest(ctx context.Context, key string, size int64, entries []*LogEntry) error {
+	minTS, maxTS := entries[0].Timestamp, entries[0].Timestamp
+	for _, e := range entries[1:] {
+		if e.Timestamp < minTS {
+			minTS = e.Timestamp
+		}
+		if e.Timestamp > maxTS {
+			maxTS = e.Timestamp
+		}
+	}
+
+	manifestKey := path.Join(u.Prefix, "_manifests", time.Now().UTC().Format("2006-01-02"), "manifest.json")
+
+	u.manifestMu.Lock()
+	defer u.manifestMu.Unlock()
+
+	var manifest []ManifestEntry
+	out, err := u.Client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(u.Bucket), Key: aws.String(manifestKey)})
+	switch {
+	case err == nil:
+		defer out.Body.Close()
+		if decodeErr := json.NewDecoder(out.Body).Decode(&manifest); decodeErr != nil {
+			return fmt.Errorf("failed to decode existing manifest %s: %w", manifestKey, decodeErr)
+		}
+	case isNoSuchKey(err):
+		// First batch of the day; start a fresh manifest.
+	default:
+		return fmt.Errorf("failed to fetch manifest %s: %w", manifestKey, err)
+	}
+
+	manifest = append(manifest, ManifestEntry{
+		Key:   key,
+		Bytes: size,
+		Rows:  len(entries),
+		MinTS: minTS,
+		MaxTS: maxTS,
+	})
+
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest %s: %w", manifestKey, err)
+	}
+
+	if _, err := u.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(u.Bucket),
+		Key:         aws.String(manifestKey),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	}); err != nil {
+		return fmt.Errorf("failed to write manifest %s: %w", manifestKey, err)
+	}
+
+	return nil
+}
+
+// isNoSuchKey reports whether err is S3's "object does not exist" error, the
+// normal condition the first time a day's manifest is written.
+func isNoSuchKey(err error) bool {
+	var nsk *types.NoSuchKey
+	return errors.As(err, &nsk)
+}
@@ -0,0 +1,97 @@
+// coredns-s3dumper/parquet.go
+package s3dumper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// memParquetFile is a minimal in-memory source.ParquetFile backed by a byte
+// slice, so encodeParquet can build a Parquet object without touching disk.
+// writer.ParquetWriter seeks backward to patch in footer offsets after the
+// row groups are written, so a plain bytes.Buffer (write-only, no Seek)
+// isn't enough here the way it is for an append-only io.Writer.
+type memParquetFile struct {
+	buf []byte
+	pos int64
+}
+
+func (f *memParquetFile) Read(p []byte) (int, error) {
+	if f.pos >= int64(len(f.buf)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.buf[f.pos:])
+	f.pos += int64(n)
+	return n, nil
+}
+
+func (f *memParquetFile) Write(p []byte) (int, error) {
+	end := f.pos + int64(len(p))
+	if end > int64(len(f.buf)) {
+		grown := make([]byte, end)
+		copy(grown, f.buf)
+		f.buf = grown
+	}
+	n := copy(f.buf[f.pos:end], p)
+	f.pos += int64(n)
+	return n, nil
+}
+
+func (f *memParquetFile) Seek(offset int64, whence int) (int64, error) {
+	pos := f.pos
+	switch whence {
+	case io.SeekStart:
+		pos = offset
+	case io.SeekCurrent:
+		pos += offset
+	case io.SeekEnd:
+		pos = int64(len(f.buf)) + offset
+	default:
+		return 0, fmt.Errorf("memParquetFile: invalid whence %d", whence)
+	}
+	if pos < 0 {
+		return 0, fmt.Errorf("memParquetFile: negative seek position %d", pos)
+	}
+	f.pos = pos
+	return pos, nil
+}
+
+func (f *memParquetFile) Close() error { return nil }
+
+func (f *memParquetFile) Open(name string) (source.ParquetFile, error) { return f, nil }
+
+func (f *memParquetFile) Create(name string) (source.ParquetFile, error) {
+	return &memParquetFile{}, nil
+}
+
+// encodeParquet writes entries to an in-memory Parquet buffer using the
+// LogEntry schema. It is shared by every backend that builds the whole
+// object in memory before handing it to its SDK's upload call (S3, B2,
+// GCS, OSS) so the Parquet encoding settings only need to be tuned once.
+func encodeParquet(entries []*LogEntry) (*bytes.Buffer, error) {
+	pf := &memParquetFile{}
+	pw, err := writer.NewParquetWriter(pf, new(LogEntry), 4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create in-memory parquet writer: %w", err)
+	}
+
+	pw.RowGroupSize = 128 * 1024 * 1024 // 128M
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	for _, entry := range entries {
+		if err := pw.Write(entry); err != nil {
+			return nil, fmt.Errorf("failed to write record to parquet buffer: %w", err)
+		}
+	}
+
+	if err := pw.WriteStop(); err != nil {
+		return nil, fmt.Errorf("failed to close parquet writer: %w", err)
+	}
+
+	return bytes.NewBuffer(pf.buf), nil
+}
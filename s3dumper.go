@@ -4,6 +4,8 @@ package s3dumper
 import (
 	"context"
 	"log"
+	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
@@ -13,195 +15,238 @@ import (
 	"github.com/miekg/dns"
 )
 
-// Uploader is the interface for uploading log entries.
-// This allows for different backends like S3 or local filesystem.
+// Uploader is the interface for uploading log entries. This allows for
+// different backends like S3 or local filesystem. Upload returns an error
+// so callers can retry or dead-letter a failed batch instead of losing it
+// silently.
 type Uploader interface {
-	Upload(entries []*LogEntry)
+	Upload(entries []*LogEntry) error
 }
 
 // S3Dumper is the main plugin struct.
 type S3Dumper struct {
-    Next        plugin.Handler
-
-    // Low-priority logging pipeline
-    queue       chan *LogEntry
-    stop        chan struct{}
-    workers     int
-
-    // Batching / backpressure
-    batchSize   int
-    flushEvery  time.Duration
-    dropThresh  int // percentage (0..100); start shedding when queue >= this%
-
-    // Existing components
-    Uploader    *Uploader
+	Next plugin.Handler
+
+	// Low-priority logging pipeline
+	queue   chan *LogEntry
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	workers int
+
+	// Batching / backpressure
+	batchSize  int
+	flushEvery time.Duration
+	dropThresh int // percentage (0..100); start shedding when queue >= this%
+
+	// Upload concurrency / retry / dead-letter
+	uploadSem         chan struct{} // bounds in-flight Uploader.Upload calls
+	uploadConcurrency int
+	maxRetries        int
+	retryBaseDelay    time.Duration
+	retryCapDelay     time.Duration
+	deadLetterPath    string
+
+	// Existing components
+	Uploader Uploader
 }
 
 func (s *S3Dumper) Init() {
-    if s.workers == 0 {
-        s.workers = 4
-    }
-    if s.batchSize == 0 {
-        s.batchSize = 1000
-    }
-    if s.flushEvery == 0 {
-        s.flushEvery = 5 * time.Second
-    }
-    if s.dropThresh == 0 {
-        s.dropThresh = 90 // start shedding when >=90% full
-    }
-    if s.queue == nil {
-        s.queue = make(chan *LogEntry, 50_000)
-    }
-    s.stop = make(chan struct{})
-
-    for i := 0; i < s.workers; i++ {
-        go s.worker()
-    }
+	if s.workers == 0 {
+		s.workers = 4
+	}
+	if s.batchSize == 0 {
+		s.batchSize = 1000
+	}
+	if s.flushEvery == 0 {
+		s.flushEvery = 5 * time.Second
+	}
+	if s.dropThresh == 0 {
+		s.dropThresh = 90 // start shedding when >=90% full
+	}
+	if s.queue == nil {
+		s.queue = make(chan *LogEntry, 50_000)
+	}
+	if s.uploadConcurrency == 0 {
+		s.uploadConcurrency = defaultUploadConcurrency
+	}
+	if s.uploadSem == nil {
+		s.uploadSem = make(chan struct{}, s.uploadConcurrency)
+	}
+	if s.maxRetries == 0 {
+		s.maxRetries = defaultMaxRetries
+	}
+	if s.retryBaseDelay == 0 {
+		s.retryBaseDelay = defaultRetryBaseDelay
+	}
+	if s.retryCapDelay == 0 {
+		s.retryCapDelay = defaultRetryCapDelay
+	}
+	s.stop = make(chan struct{})
+
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
 }
 
 // Name implements the plugin.Handler interface.
 func (s *S3Dumper) Name() string { return "s3dumper" }
 
-
 // ServeDNS implements the plugin.Handler interface.
 func (s *S3Dumper) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
-    // If you only need the question + proto + rcode, you can avoid a recorder.
-    // If you DO need the final response, keep the recorder:
-    rw := dnstest.NewRecorder(w) // ok, but note it adds overhead
-
-    start := time.Now()
-    status, err := plugin.NextOrFailure(s.Name(), s.Next, ctx, rw, r)
-
-    if r.Question != nil && len(r.Question) > 0 {
-        reqState := request.Request{W: w, Req: r}
-
-        resp := rw.Msg
-        if resp == nil {
-            // Synthesize a minimal response snapshot
-            resp = new(dns.Msg)
-            resp.SetRcode(r, dns.RcodeServerFailure)
-            if status != 0 {
-                resp.Rcode = status
-            }
-        }
-
-        entry := NewLogEntry(r, reqState.W.RemoteAddr(), reqState.Proto(), start, resp)
-
-        // Fast-path: adaptive shedding if queue close to full
-        // (avoid atomic/locks by sampling len(queue))
-        qlen := len(s.queue)
-        if cap(s.queue) > 0 && qlen*100 >= cap(s.queue)*s.dropThresh {
-            // Overloaded → skip logging altogether
-            return status, err
-        }
-
-        // Non-blocking enqueue: drop if full
-        select {
-        case s.queue <- entry:
-            // enqueued
-        default:
-            // queue full → drop silently, never block DNS
-        }
-    }
-
-    return status, err
+	// If you only need the question + proto + rcode, you can avoid a recorder.
+	// If you DO need the final response, keep the recorder:
+	rw := dnstest.NewRecorder(w) // ok, but note it adds overhead
+
+	start := time.Now()
+	status, err := plugin.NextOrFailure(s.Name(), s.Next, ctx, rw, r)
+
+	if r.Question != nil && len(r.Question) > 0 {
+		reqState := request.Request{W: w, Req: r}
+
+		resp := rw.Msg
+		if resp == nil {
+			// Synthesize a minimal response snapshot
+			resp = new(dns.Msg)
+			resp.SetRcode(r, dns.RcodeServerFailure)
+			if status != 0 {
+				resp.Rcode = status
+			}
+		}
+
+		entry := NewLogEntry(r, reqState.W.RemoteAddr(), reqState.Proto(), start, resp)
+
+		// Fast-path: adaptive shedding if queue close to full
+		// (avoid atomic/locks by sampling len(queue))
+		qlen := len(s.queue)
+		if cap(s.queue) > 0 && qlen*100 >= cap(s.queue)*s.dropThresh {
+			// Overloaded → skip logging altogether
+			return status, err
+		}
+
+		// Non-blocking enqueue: drop if full
+		select {
+		case s.queue <- entry:
+			// enqueued
+		default:
+			// queue full → drop silently, never block DNS
+		}
+	}
+
+	return status, err
 }
 
 func (s *S3Dumper) worker() {
-    ticker := time.NewTicker(s.flushEvery)
-    defer ticker.Stop()
-
-    batch := make([]*LogEntry, 0, s.batchSize)
-
-    flush := func() {
-        if len(batch) == 0 {
-            return
-        }
-        // Upload synchronously inside the worker
-        // Make a copy to avoid holding onto large backing arrays
-        toUpload := make([]*LogEntry, len(batch))
-        copy(toUpload, batch)
-        // Reset the batch quickly
-        batch = batch[:0]
-
-        // Best effort; errors are logged, not propagated to ServeDNS
-        if err := s.Uploader.Upload(toUpload); err != nil {
-            // TODO: add your logger
-            // log.Printf("[s3dumper] upload failed: %v", err)
-        }
-    }
-
-    for {
-        select {
-        case <-s.stop:
-            // drain
-            for {
-                select {
-                case e := <-s.queue:
-                    batch = append(batch, e)
-                    if len(batch) >= s.batchSize {
-                        flush()
-                    }
-                default:
-                    flush()
-                    return
-                }
-            }
-        case e := <-s.queue:
-            batch = append(batch, e)
-            if len(batch) >= s.batchSize {
-                flush()
-            }
-        case <-ticker.C:
-            flush()
-        }
-    }
-}
+	defer s.wg.Done()
 
+	ticker := time.NewTicker(s.flushEvery)
+	defer ticker.Stop()
 
+	batch := make([]*LogEntry, 0, s.batchSize)
 
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		// Make a copy to avoid holding onto large backing arrays
+		toUpload := make([]*LogEntry, len(batch))
+		copy(toUpload, batch)
+		// Reset the batch quickly
+		batch = batch[:0]
+
+		// Upload in its own goroutine so a slow batch doesn't stall this
+		// worker's draining of the queue; uploadSem (not the worker count)
+		// is what bounds how many uploads actually run at once.
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.uploadWithRetry(toUpload)
+		}()
+	}
 
-// Start runs the background ticker for flushing logs.
-func (s *S3Dumper) Start() {
-	s.stop = make(chan struct{})
-	ticker := time.NewTicker(s.FlushInterval)
-
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				toFlush := s.Buffer.Flush()
-				if toFlush != nil {
-					go s.Uploader.Upload(toFlush)
+	for {
+		select {
+		case <-s.stop:
+			// drain
+			for {
+				select {
+				case e := <-s.queue:
+					batch = append(batch, e)
+					if len(batch) >= s.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
 				}
-			case <-s.stop:
-				ticker.Stop()
-				return
 			}
+		case e := <-s.queue:
+			batch = append(batch, e)
+			if len(batch) >= s.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
 		}
-	}()
-	log.Printf("[INFO] s3dumper: started with flush interval %v", s.FlushInterval)
+	}
 }
 
-// Shutdown gracefully stops the plugin.
-func (s *S3Dumper) Shutdown() error {
-	close(s.stop)
+// uploadWithRetry uploads a batch, retrying on failure with exponential
+// backoff and jitter, and bounding how many uploads run at once via
+// uploadSem regardless of how many workers are flushing concurrently. The
+// backoff sleep aborts early if s.stop fires, so Shutdown doesn't have to
+// wait out a full retry cycle against a down/slow backend. If every attempt
+// fails (or shutdown cuts retries short), the batch is written to
+// deadLetterPath (if any) so operators can replay it later instead of
+// losing it.
+func (s *S3Dumper) uploadWithRetry(entries []*LogEntry) {
+	s.uploadSem <- struct{}{}
+	defer func() { <-s.uploadSem }()
+
+	delay := s.retryBaseDelay
+	var err error
+	aborted := false
+retry:
+	for attempt := 1; attempt <= s.maxRetries; attempt++ {
+		if err = s.Uploader.Upload(entries); err == nil {
+			return
+		}
+		log.Printf("[ERROR] s3dumper: upload attempt %d/%d failed: %v", attempt, s.maxRetries, err)
+		if attempt == s.maxRetries {
+			break
+		}
 
-	toFlush := s.Buffer.Flush()
-	if toFlush != nil {
-		log.Printf("[INFO] s3dumper: performing final flush of %d log entries on shutdown", len(toFlush))
-		s.Uploader.Upload(toFlush)
+		sleep := delay/2 + time.Duration(rand.Int63n(int64(delay)/2+1))
+		select {
+		case <-time.After(sleep):
+		case <-s.stop:
+			aborted = true
+			break retry
+		}
+		if delay *= 2; delay > s.retryCapDelay {
+			delay = s.retryCapDelay
+		}
 	}
-	log.Printf("[INFO] s3dumper: successfully shut down", s.FlushInterval)
-	return nil
-}
 
-func (s *S3Dumper) OnShutdown() error {
-    close(s.stop)
-    // Optionally wait a short grace period for workers to finish
-    // or use a sync.WaitGroup to join workers.
-    return nil
+	if aborted {
+		log.Printf("[ERROR] s3dumper: shutdown requested, abandoning retries for batch of %d entries: %v", len(entries), err)
+	} else {
+		log.Printf("[ERROR] s3dumper: giving up on batch of %d entries after %d attempts: %v", len(entries), s.maxRetries, err)
+	}
+	if s.deadLetterPath == "" {
+		log.Printf("[ERROR] s3dumper: no dead_letter_path configured, batch of %d entries is lost", len(entries))
+		return
+	}
+	if err := writeDeadLetter(s.deadLetterPath, entries); err != nil {
+		log.Printf("[ERROR] s3dumper: failed to write dead-letter batch: %v", err)
+	}
 }
 
-
+// Shutdown stops accepting new work, drains the queue, and waits for every
+// worker's final flush to finish before returning.
+func (s *S3Dumper) Shutdown() error {
+	close(s.stop)
+	s.wg.Wait()
+	log.Printf("[INFO] s3dumper: successfully shut down")
+	return nil
+}
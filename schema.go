@@ -0,0 +1,67 @@
+// coredns-s3dumper/schema.go
+package s3dumper
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SchemaVersion is bumped whenever LogEntry's Parquet schema changes in a
+// way downstream Athena/Glue table definitions need to know about; it is
+// embedded in the schema object's key so old and new readers can coexist.
+const SchemaVersion = 1
+
+// SchemaColumn describes one column of the LogEntry Parquet schema, as
+// reflected from its `parquet` struct tags.
+type SchemaColumn struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	LogicalType string `json:"logical_type,omitempty"`
+	Repeated    bool   `json:"repeated,omitempty"`
+}
+
+// Schema describes the Parquet schema of LogEntry at SchemaVersion.
+type Schema struct {
+	Version int            `json:"version"`
+	Columns []SchemaColumn `json:"columns"`
+}
+
+// buildSchema reflects on LogEntry's `parquet` struct tags to describe its
+// schema, so the manifest/schema object can't drift from the real encoder.
+func buildSchema() Schema {
+	t := reflect.TypeOf(LogEntry{})
+	schema := Schema{Version: SchemaVersion, Columns: make([]SchemaColumn, 0, t.NumField())}
+
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("parquet")
+		if tag == "" {
+			continue
+		}
+
+		col := SchemaColumn{}
+		for _, part := range strings.Split(tag, ",") {
+			kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			key, val := kv[0], kv[1]
+			switch key {
+			case "name":
+				col.Name = val
+			case "type":
+				col.Type = val
+			case "convertedtype":
+				if col.LogicalType == "" {
+					col.LogicalType = val
+				}
+			case "logicaltype":
+				col.LogicalType = val
+			case "repetitiontype":
+				col.Repeated = val == "REPEATED"
+			}
+		}
+		schema.Columns = append(schema.Columns, col)
+	}
+
+	return schema
+}
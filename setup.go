@@ -2,13 +2,17 @@
 package s3dumper
 
 import (
-	_"context"
+	"context"
+	"fmt"
 	"log"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
@@ -29,7 +33,7 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("s3dumper", err)
 	}
 
-	dumper.Start()
+	dumper.Init()
 	c.OnShutdown(func() error {
 		return dumper.Shutdown()
 	})
@@ -42,6 +46,21 @@ func setup(c *caddy.Controller) error {
 	return nil
 }
 
+// encodeObjectTags turns a comma-separated "k=v,k2=v2" list from the
+// Corefile into the URL-encoded query string S3 expects in
+// PutObjectInput.Tagging.
+func encodeObjectTags(tags string) (string, error) {
+	values := url.Values{}
+	for _, pair := range strings.Split(tags, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return "", fmt.Errorf("expected 'k=v', got %q", pair)
+		}
+		values.Set(kv[0], kv[1])
+	}
+	return values.Encode(), nil
+}
+
 func parseConfig(c *caddy.Controller) (*S3Dumper, error) {
 	// Default settings
 	batchSize := defaultBatchSize
@@ -54,6 +73,38 @@ func parseConfig(c *caddy.Controller) (*S3Dumper, error) {
 	region := ""
 	localPath := ""
 
+	// B2 specific settings
+	b2AccountID := ""
+	b2ApplicationKey := ""
+
+	// GCS specific settings
+	gcsCredentialsFile := ""
+
+	// OSS specific settings
+	ossEndpoint := ""
+	ossAccessKeyID := ""
+	ossAccessKeySecret := ""
+
+	// S3 multipart-upload settings
+	partSize := defaultPartSize
+	uploadConcurrency := defaultUploadConcurrency
+	leavePartsOnError := defaultLeavePartsOnError
+
+	// S3 storage class / SSE / tagging settings
+	storageClass := ""
+	sse := ""
+	sseKMSKeyID := ""
+	objectTags := ""
+
+	// Worker-pool retry / dead-letter settings
+	maxRetries := defaultMaxRetries
+	deadLetterPath := ""
+
+	// Object key layout settings
+	keyLayout := KeyLayoutFlat
+	keyTemplate := ""
+	fileExtension := defaultFileExtension
+
 	for c.Next() { // Skip the plugin name
 		for c.NextBlock() {
 			switch c.Val() {
@@ -82,9 +133,9 @@ func parseConfig(c *caddy.Controller) (*S3Dumper, error) {
 				if !c.NextArg() {
 					return nil, c.ArgErr()
 				}
-				storageType = c.Val() // "s3" or "local"
+				storageType = c.Val() // "s3", "b2", "gcs", "oss" or "local"
 
-			// S3 specific settings
+			// S3/B2/GCS/OSS shared settings
 			case "bucket":
 				if !c.NextArg() {
 					return nil, c.ArgErr()
@@ -108,18 +159,145 @@ func parseConfig(c *caddy.Controller) (*S3Dumper, error) {
 				}
 				localPath = c.Val()
 
+			// B2 specific settings
+			case "account_id":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				b2AccountID = c.Val()
+			case "application_key":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				b2ApplicationKey = c.Val()
+
+			// GCS specific settings
+			case "credentials_file":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				gcsCredentialsFile = c.Val()
+
+			// OSS specific settings
+			case "endpoint":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				ossEndpoint = c.Val()
+			case "access_key_id":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				ossAccessKeyID = c.Val()
+			case "access_key_secret":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				ossAccessKeySecret = c.Val()
+
+			// S3 multipart-upload settings
+			case "part_size":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				size, err := strconv.ParseInt(c.Val(), 10, 64)
+				if err != nil {
+					return nil, c.Errf("invalid part_size: %v", err)
+				}
+				partSize = size
+			case "upload_concurrency":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				concurrency, err := strconv.Atoi(c.Val())
+				if err != nil {
+					return nil, c.Errf("invalid upload_concurrency: %v", err)
+				}
+				uploadConcurrency = concurrency
+			case "leave_parts_on_error":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				leave, err := strconv.ParseBool(c.Val())
+				if err != nil {
+					return nil, c.Errf("invalid leave_parts_on_error: %v", err)
+				}
+				leavePartsOnError = leave
+
+			// S3 storage class / SSE / tagging settings
+			case "storage_class":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				storageClass = c.Val()
+			case "sse":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				sse = c.Val()
+			case "sse_kms_key_id":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				sseKMSKeyID = c.Val()
+			case "object_tags":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				objectTags = c.Val()
+
+			// Worker-pool retry / dead-letter settings
+			case "max_retries":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				retries, err := strconv.Atoi(c.Val())
+				if err != nil {
+					return nil, c.Errf("invalid max_retries: %v", err)
+				}
+				if retries < 1 {
+					return nil, c.Errf("invalid max_retries: must be >= 1, got %d", retries)
+				}
+				maxRetries = retries
+			case "dead_letter_path":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				deadLetterPath = c.Val()
+
+			// Object key layout settings
+			case "key_layout":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				keyLayout = c.Val()
+			case "key_template":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				keyTemplate = c.Val()
+			case "file_extension":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				fileExtension = c.Val()
+
 			default:
 				return nil, c.Errf("unknown property '%s'", c.Val())
 			}
 		}
 	}
 
+	keyConfig, err := NewKeyConfig(keyLayout, fileExtension, keyTemplate, region)
+	if err != nil {
+		return nil, c.Errf("%v", err)
+	}
+
 	var uploader Uploader
-	var err error
 
 	switch storageType {
 
-    case "s3":
+	case "s3":
 		if bucket == "" || region == "" {
 			return nil, c.Err("for 's3' storage, 'bucket' and 'region' must be specified")
 		}
@@ -127,27 +305,68 @@ func parseConfig(c *caddy.Controller) (*S3Dumper, error) {
 		if err != nil {
 			return nil, c.Errf("failed to load AWS config: %v", err)
 		}
-		uploader = &S3Uploader{
-			Client: s3.NewFromConfig(awsCfg),
-			Bucket: bucket,
-			Prefix: prefix,
+		s3Uploader := NewS3Uploader(s3.NewFromConfig(awsCfg), bucket, prefix, partSize, uploadConcurrency, leavePartsOnError, keyConfig)
+		if storageClass != "" {
+			s3Uploader.StorageClass = types.StorageClass(storageClass)
+		}
+		if sse != "" {
+			s3Uploader.SSE = types.ServerSideEncryption(sse)
+			s3Uploader.SSEKMSKeyID = sseKMSKeyID
+		}
+		if objectTags != "" {
+			tagging, err := encodeObjectTags(objectTags)
+			if err != nil {
+				return nil, c.Errf("invalid object_tags: %v", err)
+			}
+			s3Uploader.Tagging = tagging
+		}
+		uploader = s3Uploader
+		log.Printf("[INFO] s3dumper: configured 's3' storage for bucket '%s' in region '%s' (part_size=%d, upload_concurrency=%d, leave_parts_on_error=%t)",
+			bucket, region, partSize, uploadConcurrency, leavePartsOnError)
+	case "b2":
+		if bucket == "" || b2AccountID == "" || b2ApplicationKey == "" {
+			return nil, c.Err("for 'b2' storage, 'bucket', 'account_id' and 'application_key' must be specified")
+		}
+		uploader, err = NewB2Uploader(context.TODO(), b2AccountID, b2ApplicationKey, bucket, prefix, keyConfig)
+		if err != nil {
+			return nil, c.Errf("failed to configure b2 storage: %v", err)
+		}
+		log.Printf("[INFO] s3dumper: configured 'b2' storage for bucket '%s'", bucket)
+
+	case "gcs":
+		if bucket == "" || gcsCredentialsFile == "" {
+			return nil, c.Err("for 'gcs' storage, 'bucket' and 'credentials_file' must be specified")
 		}
-		log.Printf("[INFO] s3dumper: configured 's3' storage for bucket '%s' in region '%s'", bucket, region)
+		uploader, err = NewGCSUploader(context.TODO(), gcsCredentialsFile, bucket, prefix, keyConfig)
+		if err != nil {
+			return nil, c.Errf("failed to configure gcs storage: %v", err)
+		}
+		log.Printf("[INFO] s3dumper: configured 'gcs' storage for bucket '%s'", bucket)
+
+	case "oss":
+		if bucket == "" || ossEndpoint == "" || ossAccessKeyID == "" || ossAccessKeySecret == "" {
+			return nil, c.Err("for 'oss' storage, 'bucket', 'endpoint', 'access_key_id' and 'access_key_secret' must be specified")
+		}
+		uploader = NewOSSUploader(ossEndpoint, ossAccessKeyID, ossAccessKeySecret, bucket, prefix, keyConfig)
+		log.Printf("[INFO] s3dumper: configured 'oss' storage for bucket '%s' at endpoint '%s'", bucket, ossEndpoint)
+
 	case "local":
 		if localPath == "" {
 			return nil, c.Err("for 'local' storage, 'local_path' must be specified")
 		}
-		uploader = &LocalUploader{Path: localPath}
+		uploader = &LocalUploader{Path: localPath, FileExtension: fileExtension}
 		log.Printf("[INFO] s3dumper: configured 'local' storage at path '%s'", localPath)
 
 	default:
-		return nil, c.Errf("invalid storage_type '%s', must be 's3' or 'local'", storageType)
+		return nil, c.Errf("invalid storage_type '%s', must be one of 's3', 'b2', 'gcs', 'oss' or 'local'", storageType)
 	}
 
 	return &S3Dumper{
-		Uploader:      uploader, // Assign the chosen uploader
-		Buffer:        NewLogBuffer(batchSize),
-		FlushInterval: flushInterval,
+		Uploader:          uploader, // Assign the chosen uploader
+		batchSize:         batchSize,
+		flushEvery:        flushInterval,
+		uploadConcurrency: uploadConcurrency,
+		maxRetries:        maxRetries,
+		deadLetterPath:    deadLetterPath,
 	}, err
 }
-
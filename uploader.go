@@ -6,83 +6,142 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"path"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/google/uuid"
-	"github.com/xitongsys/parquet-go/parquet"
-	"github.com/xitongsys/parquet-go/writer"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Defaults for the multipart uploader, used when the Corefile doesn't
+// override them.
+const (
+	defaultPartSize          int64 = 16 * 1024 * 1024 // 16 MiB
+	defaultUploadConcurrency       = 5
+	defaultLeavePartsOnError       = false
+)
+
+// Defaults for the worker-pool retry/dead-letter behavior, used when the
+// Corefile doesn't override them.
+const (
+	defaultMaxRetries     = 5
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryCapDelay  = 30 * time.Second
 )
 
 // S3Uploader handles uploading logs to S3.
 type S3Uploader struct {
 	Client *s3.Client
-	Bucket string
-	Prefix string
+	// Manager performs the actual PutObject/multipart upload. It is built
+	// once from Client so part size and concurrency only need to be
+	// configured in one place.
+	Manager *manager.Uploader
+	Bucket  string
+	Prefix  string
+	// KeyConfig controls the object-key layout (flat/hive/custom).
+	KeyConfig KeyConfig
+
+	// PartSize, UploadConcurrency and LeavePartsOnError mirror the
+	// manager.Uploader options they were built from; kept here so they
+	// show up in logs/diagnostics without reaching back into Manager.
+	PartSize          int64
+	UploadConcurrency int
+	LeavePartsOnError bool
+
+	// StorageClass, SSE, SSEKMSKeyID and Tagging are applied to the
+	// PutObjectInput of every uploaded batch; left zero-valued they leave
+	// the corresponding S3 field unset so the bucket's own defaults apply.
+	// The _schema and _manifests objects are written without them.
+	StorageClass types.StorageClass
+	SSE          types.ServerSideEncryption
+	SSEKMSKeyID  string
+	Tagging      string // URL-encoded "k=v&k2=v2", see s3.PutObjectInput.Tagging
+
+	// schemaOnce guards the one-time (per SchemaVersion) write of the
+	// _schema/v<N>.json object. manifestMu serializes the read-modify-write
+	// of the rolling daily _manifests/<date>/manifest.json object across
+	// concurrent uploads.
+	schemaOnce sync.Once
+	manifestMu sync.Mutex
+}
+
+// NewS3Uploader builds an S3Uploader whose Manager is configured with the
+// given part size, concurrency and error-handling behavior.
+func NewS3Uploader(client *s3.Client, bucket, prefix string, partSize int64, uploadConcurrency int, leavePartsOnError bool, keyConfig KeyConfig) *S3Uploader {
+	mgr := manager.NewUploader(client, func(u *manager.Uploader) {
+		u.PartSize = partSize
+		u.Concurrency = uploadConcurrency
+		u.LeavePartsOnError = leavePartsOnError
+	})
+	return &S3Uploader{
+		Client:            client,
+		Manager:           mgr,
+		Bucket:            bucket,
+		Prefix:            prefix,
+		KeyConfig:         keyConfig,
+		PartSize:          partSize,
+		UploadConcurrency: uploadConcurrency,
+		LeavePartsOnError: leavePartsOnError,
+	}
 }
 
 // Upload marshals, compresses, and uploads log entries to S3 in Parquet format.
-func (u *S3Uploader) Upload(entries []*LogEntry) {
+func (u *S3Uploader) Upload(entries []*LogEntry) error {
 	if len(entries) == 0 {
-		return
+		return nil
 	}
 
-	// 1. Create an in-memory buffer and a Parquet writer
-	buf := new(bytes.Buffer)
-	pw, err := writer.NewParquetWriter(buf, new(LogEntry), 4)
+	// 1. Encode the batch to an in-memory Parquet buffer
+	buf, err := encodeParquet(entries)
 	if err != nil {
-		log.Printf("[ERROR] s3dumper: failed to create in-memory parquet writer: %v", err)
-		return
-	}
-
-	// 2. Configure Parquet writer properties
-	pw.RowGroupSize = 128 * 1024 * 1024 // 128M
-	pw.CompressionType = parquet.CompressionCodec_SNAPPY
-
-	// 3. Write each entry
-	for _, entry := range entries {
-		if err = pw.Write(entry); err != nil {
-			log.Printf("[ERROR] s3dumper: failed to write record to parquet buffer: %v", err)
-		}
-	}
-
-	// 4. Close the writer to flush all data to the buffer
-	if err = pw.Close(); err != nil {
-		log.Printf("[ERROR] s3dumper: failed to close parquet writer: %v", err)
-		return
+		return err
 	}
 
 	// 5. Generate a unique key for the S3 object
-	key := u.generateS3Key()
+	key, err := u.KeyConfig.generateKey(u.Prefix)
+	if err != nil {
+		return err
+	}
 
-	// 6. Upload the buffer's content to S3
-	_, err = u.Client.PutObject(context.TODO(), &s3.PutObjectInput{
+	// 6. Upload the buffer's content to S3 via the multipart manager, which
+	// splits into PartSize chunks and uploads up to UploadConcurrency of
+	// them in parallel once the body crosses the part-size threshold.
+	input := &s3.PutObjectInput{
 		Bucket:      aws.String(u.Bucket),
 		Key:         aws.String(key),
 		Body:        bytes.NewReader(buf.Bytes()),
 		ContentType: aws.String("application/octet-stream"), // Use a generic content type for Parquet
-	})
+	}
+	if u.StorageClass != "" {
+		input.StorageClass = u.StorageClass
+	}
+	if u.SSE != "" {
+		input.ServerSideEncryption = u.SSE
+		if u.SSE == types.ServerSideEncryptionAwsKms && u.SSEKMSKeyID != "" {
+			input.SSEKMSKeyId = aws.String(u.SSEKMSKeyID)
+		}
+	}
+	if u.Tagging != "" {
+		input.Tagging = aws.String(u.Tagging)
+	}
 
-	if err != nil {
-		log.Printf("[ERROR] s3dumper: failed to upload logs to S3 bucket %s with key %s: %v", u.Bucket, key, err)
-	} else {
-		log.Printf("[INFO] s3dumper: successfully uploaded %d log entries to s3://%s/%s", len(entries), u.Bucket, key)
+	if _, err := u.Manager.Upload(context.TODO(), input); err != nil {
+		return fmt.Errorf("failed to upload logs to S3 bucket %s with key %s: %w", u.Bucket, key, err)
+	}
+
+	log.Printf("[INFO] s3dumper: successfully uploaded %d log entries to s3://%s/%s", len(entries), u.Bucket, key)
+
+	// 7. Make the dataset self-describing: publish the schema once, and
+	// record this object in its day's manifest. Neither failure should
+	// undo the upload above, which already succeeded.
+	if err := u.publishSchema(context.TODO()); err != nil {
+		log.Printf("[ERROR] s3dumper: failed to publish schema: %v", err)
+	}
+	if err := u.appendManifest(context.TODO(), key, int64(buf.Len()), entries); err != nil {
+		log.Printf("[ERROR] s3dumper: failed to update manifest for s3://%s/%s: %v", u.Bucket, key, err)
 	}
-}
 
-// generateS3Key creates a unique, time-partitioned key.
-func (u *S3Uploader) generateS3Key() string {
-	now := time.Now().UTC()
-	uuid, _ := uuid.NewRandom()
-	// Changed extension to .parquet
-	filename := fmt.Sprintf("%d-%s.parquet", now.UnixNano(), uuid.String())
-	return path.Join(
-		u.Prefix,
-		now.Format("2006"), // Year
-		now.Format("01"),   // Month
-		now.Format("02"),   // Day
-		filename,
-	)
+	return nil
 }
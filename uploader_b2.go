@@ -0,0 +1,74 @@
+// coredns-s3dumper/uploader_b2.go
+package s3dumper
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/kurin/blazer/b2"
+)
+
+// B2Uploader handles uploading logs to Backblaze B2.
+type B2Uploader struct {
+	Bucket         *b2.Bucket
+	BucketName     string
+	AccountID      string
+	ApplicationKey string
+	Prefix         string
+	// KeyConfig controls the object-key layout (flat/hive/custom).
+	KeyConfig KeyConfig
+}
+
+// NewB2Uploader authenticates against B2 and returns an uploader bound to
+// the given bucket.
+func NewB2Uploader(ctx context.Context, accountID, applicationKey, bucketName, prefix string, keyConfig KeyConfig) (*B2Uploader, error) {
+	client, err := b2.NewClient(ctx, accountID, applicationKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate with B2: %w", err)
+	}
+
+	bucket, err := client.Bucket(ctx, bucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open B2 bucket %s: %w", bucketName, err)
+	}
+
+	return &B2Uploader{
+		Bucket:         bucket,
+		BucketName:     bucketName,
+		AccountID:      accountID,
+		ApplicationKey: applicationKey,
+		Prefix:         prefix,
+		KeyConfig:      keyConfig,
+	}, nil
+}
+
+// Upload marshals, compresses, and uploads log entries to B2 in Parquet format.
+func (u *B2Uploader) Upload(entries []*LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	buf, err := encodeParquet(entries)
+	if err != nil {
+		return err
+	}
+
+	key, err := u.KeyConfig.generateKey(u.Prefix)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	w := u.Bucket.Object(key).NewWriter(ctx)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write to B2 object %s: %w", key, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to finalize B2 object %s: %w", key, err)
+	}
+
+	log.Printf("[INFO] s3dumper: successfully uploaded %d log entries to b2://%s/%s", len(entries), u.BucketName, key)
+	return nil
+}
@@ -0,0 +1,69 @@
+// coredns-s3dumper/uploader_gcs.go
+package s3dumper
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// GCSUploader handles uploading logs to Google Cloud Storage.
+type GCSUploader struct {
+	Client          *storage.Client
+	Bucket          string
+	CredentialsFile string
+	Prefix          string
+	// KeyConfig controls the object-key layout (flat/hive/custom).
+	KeyConfig KeyConfig
+}
+
+// NewGCSUploader builds a GCS client from the given service-account
+// credentials file and returns an uploader bound to the given bucket.
+func NewGCSUploader(ctx context.Context, credentialsFile, bucket, prefix string, keyConfig KeyConfig) (*GCSUploader, error) {
+	client, err := storage.NewClient(ctx, option.WithCredentialsFile(credentialsFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %w", err)
+	}
+
+	return &GCSUploader{
+		Client:          client,
+		Bucket:          bucket,
+		CredentialsFile: credentialsFile,
+		Prefix:          prefix,
+		KeyConfig:       keyConfig,
+	}, nil
+}
+
+// Upload marshals, compresses, and uploads log entries to GCS in Parquet format.
+func (u *GCSUploader) Upload(entries []*LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	buf, err := encodeParquet(entries)
+	if err != nil {
+		return err
+	}
+
+	key, err := u.KeyConfig.generateKey(u.Prefix)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	w := u.Client.Bucket(u.Bucket).Object(key).NewWriter(ctx)
+	w.ContentType = "application/octet-stream"
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write to GCS object %s: %w", key, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to finalize GCS object %s: %w", key, err)
+	}
+
+	log.Printf("[INFO] s3dumper: successfully uploaded %d log entries to gs://%s/%s", len(entries), u.Bucket, key)
+	return nil
+}
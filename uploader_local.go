@@ -17,12 +17,14 @@ import (
 // LocalUploader handles writing logs to the local filesystem.
 type LocalUploader struct {
 	Path string
+	// FileExtension is appended to generated filenames, e.g. ".parquet".
+	FileExtension string
 }
 
 // Upload implements the Uploader interface, writing data in Parquet format.
-func (u *LocalUploader) Upload(entries []*LogEntry) {
+func (u *LocalUploader) Upload(entries []*LogEntry) error {
     if len(entries) == 0 {
-        return
+        return nil
     }
 
     // 1. Generate a unique filename with a .parquet extension
@@ -31,15 +33,13 @@ func (u *LocalUploader) Upload(entries []*LogEntry) {
 
     // 2. Ensure directory exists
     if err := os.MkdirAll(u.Path, 0755); err != nil {
-        log.Printf("[ERROR] s3dumper: failed to create log directory %s: %v", u.Path, err)
-        return
+        return fmt.Errorf("failed to create log directory %s: %w", u.Path, err)
     }
 
     // 3. Create the file
     fw, err := os.Create(fullPath)
     if err != nil {
-        log.Printf("[ERROR] s3dumper: failed to create local file %s: %v", fullPath, err)
-        return
+        return fmt.Errorf("failed to create local file %s: %w", fullPath, err)
     }
     defer fw.Close()
 
@@ -50,8 +50,7 @@ func (u *LocalUploader) Upload(entries []*LogEntry) {
     // The final argument is the number of parallel writes
     pw, err := writer.NewParquetWriter(pf, new(LogEntry), 4)
     if err != nil {
-        log.Printf("[ERROR] s3dumper: failed to create parquet writer for %s: %v", fullPath, err)
-        return
+        return fmt.Errorf("failed to create parquet writer for %s: %w", fullPath, err)
     }
 
     // 5. Configure Parquet writer properties (e.g., compression)
@@ -69,11 +68,11 @@ func (u *LocalUploader) Upload(entries []*LogEntry) {
 
     // 7. Close the writer to flush buffers and write the file footer
     if err = pw.WriteStop(); err != nil { // Use WriteStop instead of Close
-        log.Printf("[ERROR] s3dumper: failed to close parquet writer for %s: %v", fullPath, err)
-        return // Return early as the file is likely corrupt
+        return fmt.Errorf("failed to close parquet writer for %s: %w", fullPath, err) // file is likely corrupt
     }
 
     log.Printf("[INFO] s3dumper: successfully wrote %d log entries to %s", len(entries), fullPath)
+    return nil
 }
 
 // ParquetFileWrapper wraps os.File to implement source.ParquetFile interface
@@ -100,10 +99,14 @@ func (p *ParquetFileWrapper) Open(name string) (source.ParquetFile, error) {
 }
 
 // generateFilename creates a unique, time-based filename.
-// Example: 1698429600-uuid.json.gz
+// Example: 1698429600000000000-uuid.parquet
 func (u *LocalUploader) generateFilename() string {
        now := time.Now().UTC()
        uuid, _ := uuid.NewRandom()
-       return fmt.Sprintf("%d-%s.json.gz", now.UnixNano(), uuid.String())
+       ext := u.FileExtension
+       if ext == "" {
+               ext = defaultFileExtension
+       }
+       return fmt.Sprintf("%d-%s%s", now.UnixNano(), uuid.String(), ext)
 }
 
@@ -0,0 +1,60 @@
+// coredns-s3dumper/uploader_oss.go
+package s3dumper
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/denverdino/aliyungo/oss"
+)
+
+// OSSUploader handles uploading logs to Alibaba Cloud OSS.
+type OSSUploader struct {
+	Client          *oss.Client
+	Bucket          string
+	Endpoint        string
+	AccessKeyID     string
+	AccessKeySecret string
+	Prefix          string
+	// KeyConfig controls the object-key layout (flat/hive/custom).
+	KeyConfig KeyConfig
+}
+
+// NewOSSUploader builds an OSS client for the given endpoint and credentials.
+func NewOSSUploader(endpoint, accessKeyID, accessKeySecret, bucket, prefix string, keyConfig KeyConfig) *OSSUploader {
+	client := oss.NewOSSClient(oss.Region(endpoint), false, accessKeyID, accessKeySecret, true)
+	return &OSSUploader{
+		Client:          client,
+		Bucket:          bucket,
+		Endpoint:        endpoint,
+		AccessKeyID:     accessKeyID,
+		AccessKeySecret: accessKeySecret,
+		Prefix:          prefix,
+		KeyConfig:       keyConfig,
+	}
+}
+
+// Upload marshals, compresses, and uploads log entries to OSS in Parquet format.
+func (u *OSSUploader) Upload(entries []*LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	buf, err := encodeParquet(entries)
+	if err != nil {
+		return err
+	}
+
+	key, err := u.KeyConfig.generateKey(u.Prefix)
+	if err != nil {
+		return err
+	}
+
+	bucket := u.Client.Bucket(u.Bucket)
+	if err := bucket.Put(key, buf.Bytes(), "application/octet-stream", oss.Private, oss.Options{}); err != nil {
+		return fmt.Errorf("failed to upload logs to OSS bucket %s with key %s: %w", u.Bucket, key, err)
+	}
+
+	log.Printf("[INFO] s3dumper: successfully uploaded %d log entries to oss://%s/%s", len(entries), u.Bucket, key)
+	return nil
+}